@@ -0,0 +1,81 @@
+package gocache
+
+import "container/heap"
+
+// expHeap is a container/heap min-heap of items ordered by expireAt, so the
+// soonest-to-expire item is always at index 0. Items with no expiration
+// (expireAt == 0) are never pushed onto it. Each Item records its own
+// position via heapIndex so Set/Delete can fix or remove it in O(log n)
+// instead of the cache needing to scan for it.
+type expHeap []*Item
+
+func (h expHeap) Len() int { return len(h) }
+
+func (h expHeap) Less(i, j int) bool { return h[i].expireAt < h[j].expireAt }
+
+func (h expHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *expHeap) Push(x interface{}) {
+	item := x.(*Item)
+	item.heapIndex = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.heapIndex = -1
+	*h = old[:n-1]
+	return item
+}
+
+// updateExpireAt sets item's expireAt to newExpireAt and keeps it in sync
+// with the shard's expiration heap: pushing it on if it newly gained an
+// expiration, removing it if it lost one, or fixing its heap position if it
+// kept one but the deadline moved. s.mu must already be held.
+func (s *shard) updateExpireAt(item *Item, newExpireAt int64) {
+	oldExpireAt := item.expireAt
+	item.expireAt = newExpireAt
+
+	switch {
+	case oldExpireAt == 0 && newExpireAt != 0:
+		heap.Push(&s.expirations, item)
+	case oldExpireAt != 0 && newExpireAt == 0:
+		s.removeFromHeapLocked(item)
+	case oldExpireAt != 0 && newExpireAt != 0:
+		if s.inHeap(item) {
+			heap.Fix(&s.expirations, item.heapIndex)
+		}
+	}
+}
+
+func (s *shard) inHeap(item *Item) bool {
+	return item.heapIndex >= 0 && item.heapIndex < len(s.expirations) && s.expirations[item.heapIndex] == item
+}
+
+// removeFromHeapLocked removes item from the shard's expiration heap if it
+// is tracked there. s.mu must already be held.
+func (s *shard) removeFromHeapLocked(item *Item) {
+	if s.inHeap(item) {
+		heap.Remove(&s.expirations, item.heapIndex)
+	}
+}
+
+// removeItemFromBucketLocked removes item from the bucket its key hashes
+// to, matching by pointer identity. s.mu must already be held.
+func removeItemFromBucketLocked(s *shard, item *Item) {
+	h := item.Key.Hash()
+	bucket := s.items[h]
+	for i, candidate := range bucket {
+		if candidate == item {
+			s.items[h] = append(bucket[:i], bucket[i+1:]...)
+			return
+		}
+	}
+}
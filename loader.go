@@ -0,0 +1,127 @@
+package gocache
+
+import (
+	"sync"
+	"time"
+)
+
+// keyedCall is a loader invocation in flight (or just completed) for a
+// single key, used to collapse concurrent misses into one call.
+type keyedCall struct {
+	key   Key
+	wg    sync.WaitGroup
+	value interface{}
+	ttl   time.Duration
+	err   error
+}
+
+// loadGroup de-duplicates concurrent loader calls for the same key, bucketed
+// by Key.Hash and disambiguated by Equals, mirroring how shard buckets
+// resolve hash collisions elsewhere in the package.
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[uint64][]*keyedCall
+}
+
+func newLoadGroup() *loadGroup {
+	return &loadGroup{calls: make(map[uint64][]*keyedCall)}
+}
+
+// do runs fn for key, or waits for and returns the result of an
+// already-in-flight call for the same key.
+func (g *loadGroup) do(key Key, fn func() (interface{}, time.Duration, error)) (interface{}, time.Duration, error) {
+	h := key.Hash()
+
+	g.mu.Lock()
+	for _, kc := range g.calls[h] {
+		if kc.key.Equals(key) {
+			g.mu.Unlock()
+			kc.wg.Wait()
+			return kc.value, kc.ttl, kc.err
+		}
+	}
+
+	kc := &keyedCall{key: key}
+	kc.wg.Add(1)
+	g.calls[h] = append(g.calls[h], kc)
+	g.mu.Unlock()
+
+	kc.value, kc.ttl, kc.err = fn()
+	kc.wg.Done()
+
+	g.mu.Lock()
+	bucket := g.calls[h]
+	for i, c := range bucket {
+		if c == kc {
+			g.calls[h] = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(g.calls[h]) == 0 {
+		delete(g.calls, h)
+	}
+	g.mu.Unlock()
+
+	return kc.value, kc.ttl, kc.err
+}
+
+// WithRefreshBefore enables background auto-refresh: a GetOrLoad that hits
+// an item whose remaining TTL has dropped below fraction (e.g. 0.1 means
+// "refresh once less than 10% of the TTL remains") triggers an asynchronous
+// Refresh through the loader passed to that GetOrLoad, returning the
+// still-valid stale value immediately. A plain Get never triggers a refresh.
+// Items with no expiration are never auto-refreshed.
+func WithRefreshBefore(fraction float64) Option {
+	return func(c *cache) {
+		c.refreshBefore = fraction
+	}
+}
+
+// GetOrLoad returns the cached item for key if present and unexpired.
+// Otherwise it calls loader, stores the result with the TTL loader
+// returned (0 meaning the cache's default), and returns it. Concurrent
+// misses for the same key invoke loader exactly once; every caller
+// receives the same result.
+func (c Cache) GetOrLoad(key Key, loader func(Key) (interface{}, time.Duration, error)) (Item, error) {
+	if item, ok := c.Get(key); ok {
+		if c.shouldRefresh(item) {
+			go func() {
+				_, _ = c.Refresh(key, loader)
+			}()
+		}
+		return item, nil
+	}
+
+	return c.Refresh(key, loader)
+}
+
+// Refresh recomputes the value for key via loader regardless of whether it
+// is already cached, and stores the result the same way GetOrLoad does.
+// Concurrent Refresh/GetOrLoad calls for the same key still collapse into a
+// single loader invocation.
+func (c Cache) Refresh(key Key, loader func(Key) (interface{}, time.Duration, error)) (Item, error) {
+	value, ttl, err := c.loaders.do(key, func() (interface{}, time.Duration, error) {
+		return loader(key)
+	})
+	if err != nil {
+		return Item{}, err
+	}
+
+	setTTL := ttl
+	if setTTL == 0 {
+		setTTL = DefaultTTL
+	}
+	c.SetWithTTL(key, value, setTTL)
+
+	item, _ := c.Get(key)
+	return item, nil
+}
+
+func (c Cache) shouldRefresh(item Item) bool {
+	if c.refreshBefore <= 0 || item.expireAt == 0 || item.ttl <= 0 {
+		return false
+	}
+
+	remaining := time.Duration(item.expireAt - time.Now().UnixNano())
+	return float64(remaining)/float64(item.ttl) < c.refreshBefore
+}
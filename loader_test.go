@@ -0,0 +1,119 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrLoadUsesCachedValueWhenPresent(t *testing.T) {
+	c := New(0)
+	c.Set(key{id: "a"}, "cached")
+
+	called := false
+	item, err := c.GetOrLoad(key{id: "a"}, func(Key) (interface{}, time.Duration, error) {
+		called = true
+		return "loaded", 0, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", item.Value)
+	assert.False(t, called, "loader should not run on a cache hit")
+}
+
+func TestGetOrLoadCallsLoaderOnMiss(t *testing.T) {
+	c := New(0)
+
+	item, err := c.GetOrLoad(key{id: "a"}, func(Key) (interface{}, time.Duration, error) {
+		return "loaded", 0, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "loaded", item.Value)
+
+	stored, ok := c.Get(key{id: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, "loaded", stored.Value)
+}
+
+func TestGetOrLoadPropagatesLoaderError(t *testing.T) {
+	c := New(0)
+	loaderErr := errors.New("boom")
+
+	_, err := c.GetOrLoad(key{id: "a"}, func(Key) (interface{}, time.Duration, error) {
+		return nil, 0, loaderErr
+	})
+
+	assert.Equal(t, loaderErr, err)
+	_, ok := c.Get(key{id: "a"})
+	assert.False(t, ok, "a failed load should not populate the cache")
+}
+
+func TestGetOrLoadDeduplicatesConcurrentMisses(t *testing.T) {
+	c := New(0)
+
+	var calls int32
+	var wg sync.WaitGroup
+	results := make([]string, 50)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item, err := c.GetOrLoad(key{id: "shared"}, func(Key) (interface{}, time.Duration, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return "loaded once", 0, nil
+			})
+			assert.NoError(t, err)
+			results[i] = item.Value.(string)
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls, "concurrent misses for the same key should call loader once")
+	for _, r := range results {
+		assert.Equal(t, "loaded once", r)
+	}
+}
+
+func TestRefreshRecomputesRegardlessOfCurrentState(t *testing.T) {
+	c := New(0)
+	c.Set(key{id: "a"}, "stale")
+
+	item, err := c.Refresh(key{id: "a"}, func(Key) (interface{}, time.Duration, error) {
+		return "fresh", 0, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", item.Value)
+}
+
+func TestWithRefreshBeforeTriggersBackgroundRefresh(t *testing.T) {
+	var calls int32
+
+	c := New(0, WithRefreshBefore(0.5))
+	c.SetWithTTL(key{id: "a"}, "stale", 20*time.Millisecond)
+
+	time.Sleep(15 * time.Millisecond) // less than 50% of the 20ms TTL remains
+
+	item, err := c.GetOrLoad(key{id: "a"}, func(Key) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fresh", 20 * time.Millisecond, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", item.Value, "GetOrLoad should return the still-valid stale value immediately")
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, 1*time.Second, 5*time.Millisecond, "expected a background refresh to run")
+
+	refreshed, ok := c.Get(key{id: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, "fresh", refreshed.Value)
+}
@@ -0,0 +1,227 @@
+package gocache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// EvictionReason explains why an item left the cache, passed to OnEviction
+// subscribers.
+type EvictionReason int
+
+const (
+	// EvictionReasonExpired means the item's TTL had passed when Evict (or
+	// the janitor) swept it out.
+	EvictionReasonExpired EvictionReason = iota
+	// EvictionReasonCapacity means the item was the least-recently-used
+	// entry, evicted to keep the cache within WithCapacity.
+	EvictionReasonCapacity
+	// EvictionReasonDeleted means the item was removed by a Delete call.
+	EvictionReasonDeleted
+	// EvictionReasonManual means the item was removed by a DeleteAll call.
+	EvictionReasonManual
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionReasonExpired:
+		return "expired"
+	case EvictionReasonCapacity:
+		return "capacity"
+	case EvictionReasonDeleted:
+		return "deleted"
+	case EvictionReasonManual:
+		return "manual"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCapacity bounds the cache to at most n items. Once Set would exceed
+// the capacity, the least-recently-used item is evicted first. A capacity
+// of 0 (the default) means unbounded.
+func WithCapacity(n uint64) Option {
+	return func(c *cache) {
+		if n > 0 {
+			c.capacity = n
+			c.lru = newLRUList()
+		}
+	}
+}
+
+// lruList tracks recency order across the whole cache, independent of the
+// shard each item lives in, so capacity eviction picks a single global
+// least-recently-used item rather than one per shard.
+type lruList struct {
+	mu    sync.Mutex
+	list  *list.List
+	elems map[*Item]*list.Element
+}
+
+func newLRUList() *lruList {
+	return &lruList{
+		list:  list.New(),
+		elems: make(map[*Item]*list.Element),
+	}
+}
+
+// touch moves item to the front of the recency order, inserting it if it
+// isn't already tracked.
+func (l *lruList) touch(item *Item) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elems[item]; ok {
+		l.list.MoveToFront(e)
+		return
+	}
+	l.elems[item] = l.list.PushFront(item)
+}
+
+func (l *lruList) remove(item *Item) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elems[item]; ok {
+		l.list.Remove(e)
+		delete(l.elems, item)
+	}
+}
+
+func (l *lruList) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.list.Init()
+	l.elems = make(map[*Item]*list.Element)
+}
+
+func (l *lruList) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.list.Len()
+}
+
+// popOldest removes and returns the least-recently-used item, or nil if the
+// list is empty.
+func (l *lruList) popOldest() *Item {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.list.Back()
+	if e == nil {
+		return nil
+	}
+	item := e.Value.(*Item)
+	l.list.Remove(e)
+	delete(l.elems, item)
+	return item
+}
+
+// subscribers holds the OnInsertion/OnEviction callbacks registered on a
+// cache. Callbacks are always invoked with no shard lock held, so they may
+// safely call back into the cache.
+type subscribers struct {
+	mu        sync.Mutex
+	nextID    uint64
+	insertion map[uint64]func(Item)
+	eviction  map[uint64]func(EvictionReason, Item)
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{
+		insertion: make(map[uint64]func(Item)),
+		eviction:  make(map[uint64]func(EvictionReason, Item)),
+	}
+}
+
+func (s *subscribers) addInsertion(f func(Item)) func() {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.insertion[id] = f
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.insertion, id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscribers) addEviction(f func(EvictionReason, Item)) func() {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.eviction[id] = f
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.eviction, id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscribers) fireInsertion(item Item) {
+	s.mu.Lock()
+	fns := make([]func(Item), 0, len(s.insertion))
+	for _, f := range s.insertion {
+		fns = append(fns, f)
+	}
+	s.mu.Unlock()
+
+	for _, f := range fns {
+		f(item)
+	}
+}
+
+func (s *subscribers) fireEviction(reason EvictionReason, item Item) {
+	s.mu.Lock()
+	fns := make([]func(EvictionReason, Item), 0, len(s.eviction))
+	for _, f := range s.eviction {
+		fns = append(fns, f)
+	}
+	s.mu.Unlock()
+
+	for _, f := range fns {
+		f(reason, item)
+	}
+}
+
+// OnInsertion registers f to be called, outside any internal lock, whenever
+// an item is newly added to the cache. It returns a function that
+// de-registers f.
+func (c Cache) OnInsertion(f func(Item)) func() {
+	return c.cache.subs.addInsertion(f)
+}
+
+// OnEviction registers f to be called, outside any internal lock, whenever
+// an item leaves the cache. It returns a function that de-registers f.
+func (c Cache) OnEviction(f func(EvictionReason, Item)) func() {
+	return c.cache.subs.addEviction(f)
+}
+
+// removeFromShard removes item from the shard it hashes to, matching by
+// pointer identity so a concurrently-replaced item with the same key is
+// left untouched. It returns a value snapshot taken while the shard lock is
+// still held, since item may be concurrently mutated by a Set the instant
+// the lock is released, plus whether item was found and removed.
+func (c *cache) removeFromShard(item *Item) (Item, bool) {
+	s := c.shardFor(item.Key)
+	h := item.Key.Hash()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.items[h]
+	for i, candidate := range bucket {
+		if candidate == item {
+			s.items[h] = append(bucket[:i], bucket[i+1:]...)
+			s.removeFromHeapLocked(item)
+			return *item, true
+		}
+	}
+	return Item{}, false
+}
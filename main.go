@@ -0,0 +1,504 @@
+// Package gocache provides a small, dependency-free in-memory cache.
+package gocache
+
+import (
+	"container/heap"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Key is implemented by types that can be used to address an Item in the
+// cache. Hash must be stable for the lifetime of the key and consistent
+// with Equals: if a.Equals(b) then a.Hash() == b.Hash().
+//
+// Hash was added here as part of the move to sharded, hash-indexed storage;
+// previously Key required only Equals. That is an intentional breaking
+// change rather than an oversight: no wrapper can paper over it, because a
+// wrapper type that supplies Hash on a legacy key's behalf necessarily
+// changes the key's dynamic type, and an Equals implementation that
+// type-asserts its argument back to its own concrete type (the idiomatic
+// pattern, used by this package's own test keys) would then simply reject
+// it. Existing Key implementations need a real Hash method, consistent
+// with their existing Equals, to work against this version.
+type Key interface {
+	Equals(key Key) bool
+	Hash() uint64
+}
+
+// DefaultTTL tells SetWithTTL to use the cache's default expiration instead
+// of overriding it for that item.
+const DefaultTTL time.Duration = -1
+
+// Item is a single cache entry.
+type Item struct {
+	Key      Key
+	Value    interface{}
+	expireAt int64
+	ttl      time.Duration
+	// heapIndex is this item's position in its shard's expiration heap, or
+	// -1 if it has no expiration and so isn't tracked there.
+	heapIndex int
+}
+
+// Cache is a concurrency-safe, sharded key/value store.
+type Cache struct {
+	*cache
+}
+
+type cache struct {
+	shards        []*shard
+	expiration    time.Duration
+	sliding       bool
+	janitor       *janitor
+	capacity      uint64
+	lru           *lruList
+	subs          *subscribers
+	refreshBefore float64
+	loaders       *loadGroup
+}
+
+// shard holds a disjoint slice of the keyspace behind its own lock. Keys are
+// routed to a shard by Key.Hash, and within a shard are bucketed by the same
+// hash so lookups are O(1) on average instead of the O(n) linear scan the
+// previous single-slice implementation required.
+type shard struct {
+	mu          sync.RWMutex
+	items       map[uint64][]*Item
+	expirations expHeap
+}
+
+// defaultShardCount is used when New is called without WithShardCount.
+const defaultShardCount = 32
+
+// Option configures a Cache at construction time.
+type Option func(*cache)
+
+// WithShardCount overrides the number of shards the cache is split into.
+// Higher shard counts reduce lock contention under concurrent access at the
+// cost of a little extra memory. n must be greater than zero.
+func WithShardCount(n int) Option {
+	return func(c *cache) {
+		if n > 0 {
+			c.shards = newShards(n)
+		}
+	}
+}
+
+// WithSlidingExpiration makes a successful Get push an item's expiry
+// forward by its own TTL, so frequently accessed items stay cached and only
+// cold items expire. It is disabled by default.
+func WithSlidingExpiration(enabled bool) Option {
+	return func(c *cache) {
+		c.sliding = enabled
+	}
+}
+
+func newShards(n int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = &shard{items: make(map[uint64][]*Item)}
+	}
+	return shards
+}
+
+// New creates a Cache. expiration is the default TTL applied to items on
+// Set; a value of 0 means items never expire by default.
+func New(expiration time.Duration, opts ...Option) Cache {
+	c := &cache{
+		shards:     newShards(defaultShardCount),
+		expiration: expiration,
+		subs:       newSubscribers(),
+		loaders:    newLoadGroup(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return Cache{c}
+}
+
+// NewWithJanitor creates a Cache exactly like New, but also starts a
+// background goroutine that calls Evict every cleanupInterval, so expired
+// items are removed even if nothing ever reads them again. Call Stop when
+// the Cache is no longer needed to release the goroutine; if the caller
+// forgets, a finalizer stops it once the returned *Cache is garbage
+// collected.
+func NewWithJanitor(expiration, cleanupInterval time.Duration, opts ...Option) *Cache {
+	c := New(expiration, opts...)
+
+	j := &janitor{interval: cleanupInterval, stopCh: make(chan struct{})}
+	c.cache.janitor = j
+	go j.run(c.cache)
+
+	wrapper := &c
+	runtime.SetFinalizer(wrapper, func(w *Cache) {
+		w.Stop()
+	})
+
+	return wrapper
+}
+
+// Stop releases the background janitor goroutine started by NewWithJanitor,
+// if any. It is safe to call more than once and safe to call on a Cache
+// that has no janitor.
+func (c Cache) Stop() {
+	if c.cache.janitor != nil {
+		c.cache.janitor.stop()
+	}
+}
+
+// janitor periodically evicts expired items from a cache in the background.
+type janitor struct {
+	interval time.Duration
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func (j *janitor) run(c *cache) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.evict()
+		case <-j.stopCh:
+			return
+		}
+	}
+}
+
+func (j *janitor) stop() {
+	j.stopOnce.Do(func() {
+		close(j.stopCh)
+	})
+}
+
+func (c *cache) shardFor(key Key) *shard {
+	return c.shards[key.Hash()%uint64(len(c.shards))]
+}
+
+func computeExpireAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// resolveTTL maps the DefaultTTL sentinel to the cache's own expiration,
+// leaving any other value (including 0, meaning "never expires") untouched.
+func (c *cache) resolveTTL(ttl time.Duration) time.Duration {
+	if ttl == DefaultTTL {
+		return c.expiration
+	}
+	return ttl
+}
+
+// Set adds or replaces the item for key, resetting its expiration to the
+// cache's default TTL.
+func (c Cache) Set(key Key, value interface{}) {
+	c.SetWithTTL(key, value, DefaultTTL)
+}
+
+// SetWithTTL adds or replaces the item for key using ttl in place of the
+// cache's default expiration. ttl == 0 means the item never expires;
+// ttl == DefaultTTL means "use the cache's default expiration".
+func (c Cache) SetWithTTL(key Key, value interface{}, ttl time.Duration) {
+	resolvedTTL := c.resolveTTL(ttl)
+	stored, snapshot, isNew := c.store(key, value, resolvedTTL, computeExpireAt(resolvedTTL))
+	c.afterSet(stored, snapshot, isNew)
+}
+
+// store inserts or overwrites the item for key with an already-resolved ttl
+// and expireAt, keeping the shard's bucket and expiration heap in sync. It
+// returns the live item (for pointer-identity bookkeeping like the LRU
+// list), a value snapshot taken while still holding the shard lock (since
+// the live item may be mutated by a concurrent Set the instant the lock is
+// released), and whether the item is new.
+func (c *cache) store(key Key, value interface{}, ttl time.Duration, expireAt int64) (*Item, Item, bool) {
+	s := c.shardFor(key)
+	h := key.Hash()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.items[h]
+	for _, item := range bucket {
+		if item.Key.Equals(key) {
+			item.Value = value
+			item.ttl = ttl
+			s.updateExpireAt(item, expireAt)
+			return item, *item, false
+		}
+	}
+
+	stored := &Item{Key: key, Value: value, ttl: ttl, heapIndex: -1}
+	s.items[h] = append(bucket, stored)
+	if expireAt != 0 {
+		stored.expireAt = expireAt
+		heap.Push(&s.expirations, stored)
+	}
+	return stored, *stored, true
+}
+
+// afterSet runs the side effects common to every path that stores an item:
+// LRU promotion and capacity eviction, then insertion/eviction callbacks.
+// It must run with no shard lock held. snapshot is a copy of stored's
+// fields taken while store held the shard lock, since stored itself may be
+// concurrently mutated by another Set.
+func (c Cache) afterSet(stored *Item, snapshot Item, isNew bool) {
+	var evicted []Item
+	if c.lru != nil {
+		c.lru.touch(stored)
+		for c.lru.len() > int(c.capacity) {
+			oldest := c.lru.popOldest()
+			if oldest == nil || oldest == stored {
+				break
+			}
+			if removed, ok := c.removeFromShard(oldest); ok {
+				evicted = append(evicted, removed)
+			}
+		}
+	}
+
+	if isNew {
+		c.subs.fireInsertion(snapshot)
+	}
+	for _, item := range evicted {
+		c.subs.fireEviction(EvictionReasonCapacity, item)
+	}
+}
+
+// Get returns the item stored for key. The second return value is false if
+// the key is not present, or if it has expired, in which case the expired
+// item is also evicted. If the cache has sliding expiration enabled, a
+// successful Get also pushes the item's expiry forward by its own TTL.
+func (c Cache) Get(key Key) (Item, bool) {
+	s := c.shardFor(key)
+	h := key.Hash()
+
+	if c.sliding {
+		s.mu.Lock()
+		bucket := s.items[h]
+		for i, item := range bucket {
+			if !item.Key.Equals(key) {
+				continue
+			}
+			if expired(item) {
+				s.items[h] = append(bucket[:i], bucket[i+1:]...)
+				s.removeFromHeapLocked(item)
+				removed := *item
+				s.mu.Unlock()
+				if c.lru != nil {
+					c.lru.remove(item)
+				}
+				c.subs.fireEviction(EvictionReasonExpired, removed)
+				return Item{}, false
+			}
+			if item.ttl > 0 {
+				s.updateExpireAt(item, computeExpireAt(item.ttl))
+			}
+			if c.lru != nil {
+				c.lru.touch(item)
+			}
+			found := *item
+			s.mu.Unlock()
+			return found, true
+		}
+		s.mu.Unlock()
+		return Item{}, false
+	}
+
+	s.mu.RLock()
+	for _, item := range s.items[h] {
+		if item.Key.Equals(key) {
+			if expired(item) {
+				s.mu.RUnlock()
+				// A concurrent Set may already have refreshed this exact
+				// item (store() updates matching items in place) by the
+				// time we can take the write lock, so removeExpiredItem
+				// re-checks it's still the same, still-expired item by
+				// pointer identity rather than removing whatever currently
+				// matches key - which is what calling Delete(key) here
+				// would do, discarding a fresh value a racing Set just
+				// wrote.
+				removed, ok := c.removeExpiredItem(s, h, item)
+				if ok {
+					if c.lru != nil {
+						c.lru.remove(item)
+					}
+					c.subs.fireEviction(EvictionReasonExpired, removed)
+				}
+				return Item{}, false
+			}
+			found := *item
+			s.mu.RUnlock()
+			if c.lru != nil {
+				c.lru.touch(item)
+			}
+			return found, true
+		}
+	}
+	s.mu.RUnlock()
+
+	return Item{}, false
+}
+
+// removeExpiredItem removes item from shard s's bucket h if it is still
+// present there and still expired, matching by pointer identity so a
+// concurrently-replaced item with the same key is left untouched. It
+// returns a value snapshot taken while the shard lock is still held, plus
+// whether item was found and removed.
+func (c *cache) removeExpiredItem(s *shard, h uint64, item *Item) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.items[h]
+	for i, candidate := range bucket {
+		if candidate == item && expired(candidate) {
+			s.items[h] = append(bucket[:i], bucket[i+1:]...)
+			s.removeFromHeapLocked(candidate)
+			return *candidate, true
+		}
+	}
+	return Item{}, false
+}
+
+// Delete removes the item for key, if present.
+func (c Cache) Delete(key Key) {
+	s := c.shardFor(key)
+	h := key.Hash()
+
+	var removed *Item
+
+	s.mu.Lock()
+	bucket := s.items[h]
+	for i, item := range bucket {
+		if item.Key.Equals(key) {
+			s.items[h] = append(bucket[:i], bucket[i+1:]...)
+			s.removeFromHeapLocked(item)
+			removed = item
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if removed == nil {
+		return
+	}
+	if c.lru != nil {
+		c.lru.remove(removed)
+	}
+	c.subs.fireEviction(EvictionReasonDeleted, *removed)
+}
+
+// DeleteAll removes every item from the cache.
+func (c Cache) DeleteAll() {
+	var removed []Item
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for _, bucket := range s.items {
+			for _, item := range bucket {
+				removed = append(removed, *item)
+			}
+		}
+		s.items = make(map[uint64][]*Item)
+		s.expirations = s.expirations[:0]
+		s.mu.Unlock()
+	}
+
+	if c.lru != nil {
+		c.lru.clear()
+	}
+	for _, item := range removed {
+		c.subs.fireEviction(EvictionReasonManual, item)
+	}
+}
+
+// ItemCount returns the number of items currently stored, including items
+// that have expired but have not yet been evicted.
+func (c Cache) ItemCount() int {
+	count := 0
+	for _, s := range c.shards {
+		s.mu.RLock()
+		for _, bucket := range s.items {
+			count += len(bucket)
+		}
+		s.mu.RUnlock()
+	}
+	return count
+}
+
+// GetAll returns a snapshot of every non-expired item in the cache. Expired
+// items encountered along the way are evicted.
+func (c Cache) GetAll() []Item {
+	result := make([]Item, 0, c.ItemCount())
+	var removed []Item
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for h, bucket := range s.items {
+			kept := bucket[:0]
+			for _, item := range bucket {
+				if expired(item) {
+					if c.lru != nil {
+						c.lru.remove(item)
+					}
+					s.removeFromHeapLocked(item)
+					removed = append(removed, *item)
+					continue
+				}
+				result = append(result, *item)
+				kept = append(kept, item)
+			}
+			if len(kept) == 0 {
+				delete(s.items, h)
+			} else {
+				s.items[h] = kept
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	for _, item := range removed {
+		c.subs.fireEviction(EvictionReasonExpired, item)
+	}
+
+	return result
+}
+
+// Evict removes every expired item from the cache. Unlike GetAll, it does
+// not require touching an item to trigger its removal.
+func (c Cache) Evict() {
+	c.cache.evict()
+}
+
+// evict pops expired items off each shard's expiration heap in O(k log n),
+// where k is the number of expired items, instead of scanning every item.
+func (c *cache) evict() {
+	var removed []*Item
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for len(s.expirations) > 0 && expired(s.expirations[0]) {
+			item := heap.Pop(&s.expirations).(*Item)
+			removeItemFromBucketLocked(s, item)
+			removed = append(removed, item)
+		}
+		s.mu.Unlock()
+	}
+
+	for _, item := range removed {
+		if c.lru != nil {
+			c.lru.remove(item)
+		}
+		c.subs.fireEviction(EvictionReasonExpired, *item)
+	}
+}
+
+func expired(item *Item) bool {
+	return item.expireAt != 0 && item.expireAt < time.Now().UnixNano()
+}
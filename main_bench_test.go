@@ -0,0 +1,60 @@
+package gocache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func benchKey(i int) key {
+	return key{id: strconv.Itoa(i)}
+}
+
+// BenchmarkSetParallel exercises concurrent Set calls spread over a large
+// keyspace, which is the workload the shard split targets: with enough
+// distinct keys, goroutines mostly land on different shards and avoid
+// contending on a single lock.
+func BenchmarkSetParallel(b *testing.B) {
+	c := New(0)
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Set(benchKey(i), i)
+			i++
+		}
+	})
+}
+
+// BenchmarkGetParallel measures concurrent reads against a pre-populated
+// cache, where the sharded hash-map lookup replaces the old O(n) scan.
+func BenchmarkGetParallel(b *testing.B) {
+	const population = 10000
+	c := New(0)
+	for i := 0; i < population; i++ {
+		c.Set(benchKey(i), i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(benchKey(i % population))
+			i++
+		}
+	})
+}
+
+// BenchmarkSetParallelSingleShard pins every key to the same shard (via
+// WithShardCount(1)) to highlight the contention the default shard count
+// avoids.
+func BenchmarkSetParallelSingleShard(b *testing.B) {
+	c := New(0, WithShardCount(1))
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Set(benchKey(i), i)
+			i++
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacityEvictsLeastRecentlyUsedOnSet(t *testing.T) {
+	c := New(0, WithCapacity(2))
+
+	c.Set(key{id: "a"}, "val a")
+	c.Set(key{id: "b"}, "val b")
+	c.Get(key{id: "a"}) // "a" is now more recently used than "b"
+	c.Set(key{id: "c"}, "val c")
+
+	assert.Equal(t, 2, c.ItemCount())
+
+	_, ok := c.Get(key{id: "b"})
+	assert.False(t, ok, "expected least-recently-used item to be evicted")
+
+	_, ok = c.Get(key{id: "a"})
+	assert.True(t, ok)
+
+	_, ok = c.Get(key{id: "c"})
+	assert.True(t, ok)
+}
+
+func TestOnInsertionFiresForNewItemsOnly(t *testing.T) {
+	c := New(0)
+
+	var insertions []Item
+	unsubscribe := c.OnInsertion(func(item Item) {
+		insertions = append(insertions, item)
+	})
+	defer unsubscribe()
+
+	c.Set(key{id: "a"}, "val 1")
+	c.Set(key{id: "a"}, "val 2")
+	c.Set(key{id: "b"}, "val 3")
+
+	assert.Len(t, insertions, 2)
+	assert.Equal(t, "val 1", insertions[0].Value)
+	assert.Equal(t, "val 3", insertions[1].Value)
+}
+
+func TestOnEvictionFiresWithReason(t *testing.T) {
+	c := New(0, WithCapacity(1))
+
+	var reasons []EvictionReason
+	unsubscribe := c.OnEviction(func(reason EvictionReason, item Item) {
+		reasons = append(reasons, reason)
+	})
+	defer unsubscribe()
+
+	c.Set(key{id: "a"}, "val a")
+	c.Set(key{id: "b"}, "val b") // evicts "a" for capacity
+
+	c.Delete(key{id: "b"})
+
+	c.Set(key{id: "c"}, "val c")
+	c.DeleteAll()
+
+	assert.Equal(t, []EvictionReason{
+		EvictionReasonCapacity,
+		EvictionReasonDeleted,
+		EvictionReasonManual,
+	}, reasons)
+}
+
+func TestOnEvictionFiresForExpiredItems(t *testing.T) {
+	c := New(1 * time.Nanosecond)
+
+	var reasons []EvictionReason
+	unsubscribe := c.OnEviction(func(reason EvictionReason, item Item) {
+		reasons = append(reasons, reason)
+	})
+	defer unsubscribe()
+
+	c.Set(key{id: "a"}, "val a")
+	time.Sleep(1 * time.Millisecond)
+	c.Evict()
+
+	assert.Equal(t, []EvictionReason{EvictionReasonExpired}, reasons)
+}
+
+func TestUnsubscribeStopsFurtherCallbacks(t *testing.T) {
+	c := New(0)
+
+	calls := 0
+	unsubscribe := c.OnInsertion(func(item Item) {
+		calls++
+	})
+
+	c.Set(key{id: "a"}, "val 1")
+	unsubscribe()
+	c.Set(key{id: "b"}, "val 2")
+
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,32 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+// BenchmarkEvictSparseExpiry populates a cache with a large number of items
+// on staggered, mostly-future TTLs and measures Evict. Because expired
+// items are tracked in a per-shard min-heap, Evict only pays for the items
+// actually due (k) rather than walking all n stored items.
+func BenchmarkEvictSparseExpiry(b *testing.B) {
+	const population = 100000
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c := New(0)
+		for j := 0; j < population; j++ {
+			// Spread deadlines across a wide future window so only a
+			// small, known slice is already due at any given Evict call.
+			ttl := time.Duration(j%1000+1) * time.Minute
+			if j%1000 == 0 {
+				ttl = 1 * time.Nanosecond
+			}
+			c.SetWithTTL(benchKey(j), j, ttl)
+		}
+		time.Sleep(1 * time.Millisecond)
+		b.StartTimer()
+
+		c.Evict()
+	}
+}
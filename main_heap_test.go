@@ -0,0 +1,60 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictOnlyRemovesItemsPastTheirOwnDeadline(t *testing.T) {
+	c := New(0)
+
+	c.SetWithTTL(key{id: "due"}, "val 1", 1*time.Nanosecond)
+	c.SetWithTTL(key{id: "not due"}, "val 2", 1*time.Hour)
+	c.SetWithTTL(key{id: "never"}, "val 3", 0)
+
+	time.Sleep(1 * time.Millisecond)
+	c.Evict()
+
+	assert.Equal(t, 2, c.ItemCount())
+	_, ok := c.Get(key{id: "due"})
+	assert.False(t, ok)
+	_, ok = c.Get(key{id: "not due"})
+	assert.True(t, ok)
+	_, ok = c.Get(key{id: "never"})
+	assert.True(t, ok)
+}
+
+func TestEvictWithManyStaggeredTTLsRemovesExactlyTheExpiredOnes(t *testing.T) {
+	c := New(0)
+
+	const population = 500
+	for i := 0; i < population; i++ {
+		ttl := time.Duration(i+1) * time.Hour
+		if i%10 == 0 {
+			ttl = 1 * time.Nanosecond
+		}
+		c.SetWithTTL(benchKey(i), i, ttl)
+	}
+
+	time.Sleep(1 * time.Millisecond)
+	c.Evict()
+
+	assert.Equal(t, population-population/10, c.ItemCount())
+}
+
+func TestSetWithTTLChangingExpirationKeepsHeapConsistent(t *testing.T) {
+	c := New(0)
+
+	c.SetWithTTL(key{id: "a"}, "val 1", 1*time.Hour)
+	c.SetWithTTL(key{id: "a"}, "val 2", 1*time.Nanosecond) // now due almost immediately
+	c.SetWithTTL(key{id: "a"}, "val 3", 0)                 // now never expires
+
+	time.Sleep(1 * time.Millisecond)
+	c.Evict()
+
+	val, ok := c.Get(key{id: "a"})
+	assert.True(t, ok, "last SetWithTTL cleared the expiration, so Evict must not remove it")
+	assert.Equal(t, "val 3", val.Value)
+}
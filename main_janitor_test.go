@@ -0,0 +1,49 @@
+package gocache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestJanitorRemovesExpiredItemsWithoutAnyRead(t *testing.T) {
+	c := NewWithJanitor(1*time.Nanosecond, 5*time.Millisecond)
+	defer c.Stop()
+
+	c.Set(key{id: "first id"}, "val 1")
+
+	assert := func(cond bool, msg string) {
+		if !cond {
+			t.Fatal(msg)
+		}
+	}
+	assert(c.ItemCount() == 1, "expected item to be stored before the janitor runs")
+
+	deadline := time.Now().Add(1 * time.Second)
+	for time.Now().Before(deadline) {
+		if c.ItemCount() == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("expected janitor to remove the expired item without a read")
+}
+
+func TestStopPreventsJanitorGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c := NewWithJanitor(0, 1*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	c.Stop()
+
+	deadline := time.Now().Add(1 * time.Second)
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= before {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("janitor goroutine still running after Stop: had %d goroutines, now %d", before, runtime.NumGoroutine())
+}
@@ -1,6 +1,8 @@
 package gocache
 
 import (
+	"container/heap"
+	"hash/fnv"
 	"strconv"
 	"sync"
 	"testing"
@@ -35,6 +37,30 @@ func (k key) Equals(keyComp Key) bool {
 	return k.id == keyComp.(key).id
 }
 
+func (k key) Hash() uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(k.id))
+	return h.Sum64()
+}
+
+// newTestCache seeds a Cache directly, bypassing Set, so tests can install
+// items with pre-computed expireAt values (including already-expired ones).
+func newTestCache(expiration time.Duration, items []*Item) Cache {
+	c := New(expiration)
+
+	for _, item := range items {
+		item.heapIndex = -1
+		s := c.shardFor(item.Key)
+		h := item.Key.Hash()
+		s.items[h] = append(s.items[h], item)
+		if item.expireAt != 0 {
+			heap.Push(&s.expirations, item)
+		}
+	}
+
+	return c
+}
+
 func TestSetItem(t *testing.T) {
 	testData := []testData{
 		{
@@ -45,8 +71,8 @@ func TestSetItem(t *testing.T) {
 			},
 			expected: expected{
 				items: []Item{
-					{Key: key{id: "first id", value: 1}, Value: "val 1"},
-					{Key: key{id: "second id", value: 2}, Value: "val 2"},
+					{Key: key{id: "first id", value: 1}, Value: "val 1", heapIndex: -1},
+					{Key: key{id: "second id", value: 2}, Value: "val 2", heapIndex: -1},
 				},
 				itemCount: 2,
 			},
@@ -60,8 +86,8 @@ func TestSetItem(t *testing.T) {
 			},
 			expected: expected{
 				items: []Item{
-					{Key: key{id: "first id", value: 1}, Value: "val 3"},
-					{Key: key{id: "second id", value: 2}, Value: "val 2"},
+					{Key: key{id: "first id", value: 1}, Value: "val 3", heapIndex: -1},
+					{Key: key{id: "second id", value: 2}, Value: "val 2", heapIndex: -1},
 				},
 				itemCount: 2,
 			},
@@ -77,8 +103,8 @@ func TestSetItem(t *testing.T) {
 			}
 
 			assert.Equal(t, td.expected.itemCount, c.ItemCount())
-			for _, item := range c.items {
-				assert.Contains(t, td.expected.items, *item)
+			for _, item := range c.GetAll() {
+				assert.Contains(t, td.expected.items, item)
 			}
 		})
 	}
@@ -144,15 +170,15 @@ func TestSetItemWithLazyEviction(t *testing.T) {
 			},
 		},
 		{
-			message:    "Should evict old item and add new one for cache with expiration of 1ns",
-			expiration: 1 * time.Nanosecond,
+			message:    "Should update expiration and value on existing item for cache with expiration of 1h",
+			expiration: 1 * time.Hour,
 			items: []Item{
 				{Key: key{id: "first id", value: 1}, Value: "val 1"},
 				{Key: key{id: "first id", value: 2}, Value: "val 3"},
 			},
 			expected: expected{
 				items: []Item{
-					{Key: key{id: "first id", value: 2}, Value: "val 3"},
+					{Key: key{id: "first id", value: 1}, Value: "val 3"},
 				},
 				itemCount: 1,
 			},
@@ -165,13 +191,13 @@ func TestSetItemWithLazyEviction(t *testing.T) {
 
 			for _, item := range td.items {
 				c.Set(item.Key, item.Value)
-				time.Sleep(1 * time.Nanosecond)
 			}
 
+			got := c.GetAll()
 			assert.Equal(t, td.expected.itemCount, c.ItemCount())
-			assert.Equal(t, td.expected.items[0].Key, c.items[0].Key)
-			assert.Equal(t, td.expected.items[0].Value, c.items[0].Value)
-			assert.NotNil(t, c.items[0].expireAt)
+			assert.Equal(t, td.expected.items[0].Key, got[0].Key)
+			assert.Equal(t, td.expected.items[0].Value, got[0].Value)
+			assert.NotZero(t, got[0].expireAt)
 		})
 	}
 }
@@ -186,14 +212,10 @@ func TestGetItem(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
 			message: "Should get item that exists in cache",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1"},
-						{Key: key{id: "second id", value: 2}, Value: "val 2"},
-					},
-				},
-			},
+			cache: newTestCache(0, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1"},
+				{Key: key{id: "second id", value: 2}, Value: "val 2"},
+			}),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "first id", value: 1}, Value: "val 1"},
@@ -203,7 +225,7 @@ func TestGetItem(t *testing.T) {
 		},
 		{
 			message: "Should get not ok when item does not exist in empty cache",
-			cache:   Cache{&cache{}},
+			cache:   newTestCache(0, nil),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "item does not exist", value: 1}, Value: "val 3"},
@@ -213,13 +235,9 @@ func TestGetItem(t *testing.T) {
 		},
 		{
 			message: "Should get not ok when item does not exist in cache",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first different id", value: 1}, Value: "val 1"},
-					},
-				},
-			},
+			cache: newTestCache(0, []*Item{
+				{Key: key{id: "first different id", value: 1}, Value: "val 1"},
+			}),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "item does not exist", value: 1}, Value: "val 3"},
@@ -249,25 +267,21 @@ func TestGetAllItems(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
 			message: "Should get all items that exist in cache",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1"},
-						{Key: key{id: "second id", value: 2}, Value: "val 2"},
-					},
-				},
-			},
+			cache: newTestCache(0, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1"},
+				{Key: key{id: "second id", value: 2}, Value: "val 2"},
+			}),
 			expected: expected{
 				items: []Item{
-					{Key: key{id: "first id", value: 1}, Value: "val 1"},
-					{Key: key{id: "second id", value: 2}, Value: "val 2"},
+					{Key: key{id: "first id", value: 1}, Value: "val 1", heapIndex: -1},
+					{Key: key{id: "second id", value: 2}, Value: "val 2", heapIndex: -1},
 				},
 				itemCount: 2,
 			},
 		},
 		{
-			message: "Should get empty items list if no items in cache",
-			cache:   Cache{&cache{}},
+			message:  "Should get empty items list if no items in cache",
+			cache:    newTestCache(0, nil),
 			expected: expected{
 				items:     []Item{},
 				itemCount: 0,
@@ -279,9 +293,8 @@ func TestGetAllItems(t *testing.T) {
 		t.Run(td.message, func(t *testing.T) {
 			items := td.cache.GetAll()
 			assert.Equal(t, td.expected.itemCount, len(items))
-			for i, item := range items {
-				assert.Equal(t, td.expected.items[i].Value, item.Value)
-				assert.Equal(t, td.expected.items[i].Key, item.Key)
+			for _, expectedItem := range td.expected.items {
+				assert.Contains(t, items, expectedItem)
 			}
 		})
 	}
@@ -291,15 +304,10 @@ func TestGetAllItemsWIthExpiration(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
 			message: "Should get empty list if all items have expired",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+			}),
 			expected: expected{
 				items:     []Item{},
 				itemCount: 0,
@@ -307,16 +315,11 @@ func TestGetAllItemsWIthExpiration(t *testing.T) {
 		},
 		{
 			message: "Should get only not expired items and remove the rest",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-						{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+				{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+			}),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "first id", value: 1}, Value: "val 1"},
@@ -331,9 +334,14 @@ func TestGetAllItemsWIthExpiration(t *testing.T) {
 		t.Run(td.message, func(t *testing.T) {
 			items := td.cache.GetAll()
 			assert.Equal(t, td.expected.itemCount, len(items))
-			for i, item := range items {
-				assert.Equal(t, td.expected.items[i].Value, item.Value)
-				assert.Equal(t, td.expected.items[i].Key, item.Key)
+			for _, expectedItem := range td.expected.items {
+				found := false
+				for _, item := range items {
+					if item.Key == expectedItem.Key && item.Value == expectedItem.Value {
+						found = true
+					}
+				}
+				assert.True(t, found, "expected item %v to be present", expectedItem)
 			}
 		})
 	}
@@ -343,14 +351,9 @@ func TestDeleteItem(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
 			message: "Should have empty cache after deleting single item",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "item to delete", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "item to delete", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
+			}),
 			expected: expected{
 				items:     []Item{},
 				itemCount: 0,
@@ -358,16 +361,11 @@ func TestDeleteItem(t *testing.T) {
 		},
 		{
 			message: "Should have single item in cache after deleting single item",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "item to delete", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() - 10},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+				{Key: key{id: "item to delete", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+			}),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "first id", value: 1}, Value: "val 1"},
@@ -378,14 +376,9 @@ func TestDeleteItem(t *testing.T) {
 		},
 		{
 			message: "Should not change cache if item does not exist",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+			}),
 			expected: expected{
 				items: []Item{
 					{Key: key{id: "first id", value: 1}, Value: "val 1"},
@@ -405,9 +398,10 @@ func TestDeleteItem(t *testing.T) {
 			td.cache.Delete(key)
 
 			assert.Equal(t, td.expected.itemCount, td.cache.ItemCount())
-			for i, item := range td.cache.GetAll() {
-				assert.Equal(t, td.expected.items[i].Value, item.Value)
-				assert.Equal(t, td.expected.items[i].Key, item.Key)
+			for _, expectedItem := range td.expected.items {
+				val, ok := td.cache.Get(expectedItem.Key)
+				assert.True(t, ok)
+				assert.Equal(t, expectedItem.Value, val.Value)
 			}
 		})
 	}
@@ -416,32 +410,18 @@ func TestDeleteItem(t *testing.T) {
 func TestDeleteAllItems(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
-			message: "Should not do anything if cache already empty",
-			cache: Cache{
-				&cache{
-					items:      []*Item{},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
-			expected: expected{
-				itemCount: 0,
-			},
+			message:  "Should not do anything if cache already empty",
+			cache:    newTestCache(1*time.Nanosecond, nil),
+			expected: expected{itemCount: 0},
 		},
 		{
 			message: "Should remove all items from non empty cache",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-						{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() - 10},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
-			expected: expected{
-				itemCount: 0,
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+				{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() - 10},
+			}),
+			expected: expected{itemCount: 0},
 		},
 	}
 
@@ -458,34 +438,20 @@ func TestEvictItems(t *testing.T) {
 	testData := []testDataWithExistingCache{
 		{
 			message: "Should have empty cache after evicting all expired items",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
-			expected: expected{
-				itemCount: 0,
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+			}),
+			expected: expected{itemCount: 0},
 		},
 		{
 			message: "Should only have two unexpired items in cache",
-			cache: Cache{
-				&cache{
-					items: []*Item{
-						{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-						{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
-						{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
-					},
-					expiration: 1 * time.Nanosecond,
-				},
-			},
-			expected: expected{
-				itemCount: 2,
-			},
+			cache: newTestCache(1*time.Nanosecond, []*Item{
+				{Key: key{id: "first id", value: 1}, Value: "val 1", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+				{Key: key{id: "second id", value: 2}, Value: "val 2", expireAt: time.Now().UnixNano() - 10},
+				{Key: key{id: "third id", value: 3}, Value: "val 3", expireAt: time.Now().UnixNano() + 10*time.Minute.Nanoseconds()},
+			}),
+			expected: expected{itemCount: 2},
 		},
 	}
 
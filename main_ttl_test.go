@@ -0,0 +1,80 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetWithTTLOverridesCacheDefault(t *testing.T) {
+	c := New(1 * time.Hour)
+
+	c.SetWithTTL(key{id: "short lived"}, "val 1", 1*time.Nanosecond)
+	c.SetWithTTL(key{id: "no expiration"}, "val 2", 0)
+	c.Set(key{id: "cache default"}, "val 3")
+
+	time.Sleep(1 * time.Millisecond)
+
+	_, ok := c.Get(key{id: "short lived"})
+	assert.False(t, ok, "item with a 1ns TTL should have expired")
+
+	val, ok := c.Get(key{id: "no expiration"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 2", val.Value)
+
+	val, ok = c.Get(key{id: "cache default"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 3", val.Value)
+}
+
+func TestSetWithDefaultTTLSentinelUsesCacheExpiration(t *testing.T) {
+	c := New(1 * time.Nanosecond)
+
+	c.SetWithTTL(key{id: "first id"}, "val 1", DefaultTTL)
+	time.Sleep(1 * time.Millisecond)
+
+	_, ok := c.Get(key{id: "first id"})
+	assert.False(t, ok, "DefaultTTL should fall back to the cache's 1ns expiration")
+}
+
+func TestSlidingExpirationKeepsHotItemsAlive(t *testing.T) {
+	c := New(30*time.Millisecond, WithSlidingExpiration(true))
+	c.Set(key{id: "hot"}, "val 1")
+
+	deadline := time.Now().Add(150 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		_, ok := c.Get(key{id: "hot"})
+		assert.True(t, ok, "repeated Get calls should keep sliding the expiry forward")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWithoutSlidingExpirationColdItemExpires(t *testing.T) {
+	c := New(20 * time.Millisecond)
+	c.Set(key{id: "cold"}, "val 1")
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, ok := c.Get(key{id: "cold"})
+	assert.False(t, ok)
+}
+
+func TestMixedTTLsCoexistInSameCache(t *testing.T) {
+	c := New(1 * time.Hour)
+
+	c.Set(key{id: "default ttl"}, "val 1")
+	c.SetWithTTL(key{id: "short ttl"}, "val 2", 1*time.Nanosecond)
+	c.SetWithTTL(key{id: "no ttl"}, "val 3", 0)
+
+	time.Sleep(1 * time.Millisecond)
+	c.Evict()
+
+	assert.Equal(t, 2, c.ItemCount())
+
+	_, ok := c.Get(key{id: "default ttl"})
+	assert.True(t, ok)
+
+	_, ok = c.Get(key{id: "no ttl"})
+	assert.True(t, ok)
+}
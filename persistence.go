@@ -0,0 +1,95 @@
+package gocache
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"time"
+)
+
+// persistedItem is the encoding/gob wire format for a saved Item. expireAt
+// is stored as an absolute UnixNano timestamp, not a duration, so an
+// item's deadline survives however long the cache was stopped.
+type persistedItem struct {
+	Key      Key
+	Value    interface{}
+	ExpireAt int64
+	TTL      time.Duration
+}
+
+// Save writes every non-expired item in the cache to w using encoding/gob.
+// The concrete types behind Key and the item values must have been passed
+// to gob.Register beforehand, since gob needs to know what to encode behind
+// those interface fields; Load requires the same registration to decode
+// them back.
+func (c Cache) Save(w io.Writer) error {
+	items := c.GetAll()
+
+	persisted := make([]persistedItem, 0, len(items))
+	for _, item := range items {
+		persisted = append(persisted, persistedItem{
+			Key:      item.Key,
+			Value:    item.Value,
+			ExpireAt: item.expireAt,
+			TTL:      item.ttl,
+		})
+	}
+
+	return gob.NewEncoder(w).Encode(persisted)
+}
+
+// SaveFile writes the cache to path, creating it if it doesn't exist and
+// truncating it if it does.
+func (c Cache) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.Save(f)
+}
+
+// Load reads items written by Save from r and adds them to the cache,
+// dropping any whose saved expiry has already passed at load time.
+// Existing items with the same key are overwritten, and its TTL and
+// absolute expiry are preserved exactly as saved.
+func (c Cache) Load(r io.Reader) error {
+	var persisted []persistedItem
+	if err := gob.NewDecoder(r).Decode(&persisted); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	for _, p := range persisted {
+		if p.ExpireAt != 0 && p.ExpireAt < now {
+			continue
+		}
+		stored, snapshot, isNew := c.store(p.Key, p.Value, p.TTL, p.ExpireAt)
+		c.afterSet(stored, snapshot, isNew)
+	}
+
+	return nil
+}
+
+// LoadFile reads items written by SaveFile from path and adds them to the
+// cache.
+func (c Cache) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.Load(f)
+}
+
+// NewFrom creates a Cache with the given default expiration and populates
+// it from r, as written by Save.
+func NewFrom(defaultExpiration time.Duration, r io.Reader) (Cache, error) {
+	c := New(defaultExpiration)
+	if err := c.Load(r); err != nil {
+		return Cache{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gobKey is a Key with an exported field, used only in this file. The
+// shared test fixture `key` type has no exported fields, which
+// encoding/gob refuses to encode as the concrete type behind an
+// interface{}.
+type gobKey struct {
+	ID string
+}
+
+func (k gobKey) Equals(keyComp Key) bool {
+	other, ok := keyComp.(gobKey)
+	return ok && k.ID == other.ID
+}
+
+func (k gobKey) Hash() uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(k.ID))
+	return h.Sum64()
+}
+
+func init() {
+	gob.Register(gobKey{})
+	gob.Register("")
+}
+
+func TestSaveAndLoadRoundTripsPopulatedCache(t *testing.T) {
+	c := New(0)
+	c.Set(gobKey{ID: "no ttl"}, "val 1")
+	c.SetWithTTL(gobKey{ID: "with ttl"}, "val 2", 1*time.Hour)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Save(&buf))
+
+	loaded := New(0)
+	assert.NoError(t, loaded.Load(&buf))
+
+	assert.Equal(t, 2, loaded.ItemCount())
+
+	item, ok := loaded.Get(gobKey{ID: "no ttl"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 1", item.Value)
+
+	item, ok = loaded.Get(gobKey{ID: "with ttl"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 2", item.Value)
+}
+
+func TestLoadDropsItemsAlreadyExpired(t *testing.T) {
+	c := New(0)
+	c.SetWithTTL(gobKey{ID: "expired"}, "val 1", 20*time.Millisecond)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Save(&buf))
+
+	time.Sleep(30 * time.Millisecond) // the saved item expires before Load runs
+
+	loaded := New(0)
+	assert.NoError(t, loaded.Load(&buf))
+
+	assert.Equal(t, 0, loaded.ItemCount())
+}
+
+func TestNewFromPopulatesCacheFromReader(t *testing.T) {
+	c := New(0)
+	c.Set(gobKey{ID: "a"}, "val 1")
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Save(&buf))
+
+	loaded, err := NewFrom(0, &buf)
+	assert.NoError(t, err)
+
+	item, ok := loaded.Get(gobKey{ID: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 1", item.Value)
+}
+
+func TestSaveFileAndLoadFileRoundTrip(t *testing.T) {
+	c := New(0)
+	c.Set(gobKey{ID: "a"}, "val 1")
+
+	path := t.TempDir() + "/cache.gob"
+	assert.NoError(t, c.SaveFile(path))
+
+	loaded := New(0)
+	assert.NoError(t, loaded.LoadFile(path))
+
+	item, ok := loaded.Get(gobKey{ID: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, "val 1", item.Value)
+}